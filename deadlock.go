@@ -0,0 +1,187 @@
+package kemu
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeadlockKind distinguishes the two situations OnPotentialDeadlock can be
+// reported for.
+type DeadlockKind int
+
+const (
+	// DeadlockKindHold reports that a lock has been held longer than
+	// Options.HoldTimeout.
+	DeadlockKindHold DeadlockKind = iota
+	// DeadlockKindWait reports that a Lock/LockContext call has been
+	// waiting longer than Options.WaitTimeout.
+	DeadlockKindWait
+)
+
+// DeadlockInfo describes a potential deadlock detected for a key.
+type DeadlockInfo[K comparable] struct {
+	Key         K
+	Kind        DeadlockKind
+	GoroutineID int64
+	Duration    time.Duration
+	// Stack is the stack of the goroutine the report concerns: the long
+	// holder for DeadlockKindHold, the long waiter for DeadlockKindWait.
+	Stack []byte
+	// HolderStack is only set for DeadlockKindWait, and holds the stack
+	// of the goroutine currently holding the key, if it is still known.
+	HolderStack []byte
+}
+
+// Options configures optional deadlock / long-hold detection on a Mutex.
+// Because a keyed Mutex only ever guards a single key per lockEntry,
+// detection doesn't need full lock-order graph analysis: it reduces to a
+// per-acquisition hold timer and a per-waiter wait timer.
+//
+// Detection is opt-in: calling New with no options leaves it disabled and
+// adds no overhead (no goroutines started, no stacks captured).
+type Options[K comparable] struct {
+	// HoldTimeout, if positive, reports a potential deadlock when a lock
+	// is held longer than this.
+	HoldTimeout time.Duration
+	// WaitTimeout, if positive, reports a potential deadlock when a Lock
+	// or LockContext call waits longer than this to acquire a key.
+	WaitTimeout time.Duration
+	// OnPotentialDeadlock is invoked from a background goroutine when
+	// either timeout above is exceeded.
+	OnPotentialDeadlock func(DeadlockInfo[K])
+}
+
+// Option configures a Mutex created via New.
+type Option[K comparable] func(*Options[K])
+
+// WithHoldTimeout reports a potential deadlock when a lock is held longer
+// than d.
+func WithHoldTimeout[K comparable](d time.Duration) Option[K] {
+	return func(o *Options[K]) { o.HoldTimeout = d }
+}
+
+// WithWaitTimeout reports a potential deadlock when a Lock or LockContext
+// call waits longer than d to acquire a key.
+func WithWaitTimeout[K comparable](d time.Duration) Option[K] {
+	return func(o *Options[K]) { o.WaitTimeout = d }
+}
+
+// WithOnPotentialDeadlock sets the callback invoked when HoldTimeout or
+// WaitTimeout is exceeded.
+func WithOnPotentialDeadlock[K comparable](fn func(DeadlockInfo[K])) Option[K] {
+	return func(o *Options[K]) { o.OnPotentialDeadlock = fn }
+}
+
+// detection is the acquisition-time bookkeeping kept on a held lockEntry,
+// used to report DeadlockKindHold/DeadlockKindWait.
+type detection struct {
+	goroutineID int64
+	stack       []byte
+	holdTimer   *time.Timer
+}
+
+// onAcquire records holder information for le once key has been acquired,
+// and arms the hold timer if configured. It is a no-op when detection is
+// disabled.
+func (km *Mutex[K]) onAcquire(key K, le *lockEntry) {
+	if km.opts == nil {
+		return
+	}
+
+	det := &detection{
+		goroutineID: goroutineID(),
+		stack:       stack(),
+	}
+	le.det = det
+
+	if km.opts.HoldTimeout > 0 && km.opts.OnPotentialDeadlock != nil {
+		det.holdTimer = time.AfterFunc(km.opts.HoldTimeout, func() {
+			km.opts.OnPotentialDeadlock(DeadlockInfo[K]{
+				Key:         key,
+				Kind:        DeadlockKindHold,
+				GoroutineID: det.goroutineID,
+				Duration:    km.opts.HoldTimeout,
+				Stack:       det.stack,
+			})
+		})
+	}
+}
+
+// onRelease stops le's hold timer, if any, and clears its holder info.
+// Callers must hold km.mu.
+func (km *Mutex[K]) onRelease(le *lockEntry) {
+	if le.det == nil {
+		return
+	}
+	if le.det.holdTimer != nil {
+		le.det.holdTimer.Stop()
+	}
+	le.det = nil
+}
+
+// onWaitStart arms a wait timer for a caller about to block on key, if
+// configured, and returns a function that must be called once the wait
+// ends (whatever the outcome) to disarm it.
+func (km *Mutex[K]) onWaitStart(key K, le *lockEntry) func() {
+	if km.opts == nil || km.opts.WaitTimeout <= 0 || km.opts.OnPotentialDeadlock == nil {
+		return func() {}
+	}
+
+	waiterID := goroutineID()
+	waiterStack := stack()
+
+	km.mu.Lock()
+	gen := le.generation
+	km.mu.Unlock()
+
+	timer := time.AfterFunc(km.opts.WaitTimeout, func() {
+		km.mu.Lock()
+		var holderStack []byte
+		// le may have been recycled by putLockEntry for an unrelated key
+		// between arming this timer and it firing (Timer.Stop can't
+		// interrupt a callback that already started); the generation
+		// check catches that so a stale callback doesn't attribute a
+		// different key's holder to this wait.
+		if le.generation == gen && le.det != nil {
+			holderStack = le.det.stack
+		}
+		km.mu.Unlock()
+
+		km.opts.OnPotentialDeadlock(DeadlockInfo[K]{
+			Key:         key,
+			Kind:        DeadlockKindWait,
+			GoroutineID: waiterID,
+			Duration:    km.opts.WaitTimeout,
+			Stack:       waiterStack,
+			HolderStack: holderStack,
+		})
+	})
+	return func() { timer.Stop() }
+}
+
+// goroutineID extracts the calling goroutine's ID from its stack trace.
+// It is only used for deadlock diagnostics, where best-effort is enough.
+func goroutineID() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	fields := strings.Fields(string(buf[:n]))
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseInt(fields[1], 10, 64)
+	return id
+}
+
+// stack captures the calling goroutine's current stack trace.
+func stack() []byte {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
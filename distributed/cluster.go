@@ -0,0 +1,265 @@
+package distributed
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/modfin/kemu"
+)
+
+const (
+	defaultTTL        = 10 * time.Second
+	defaultRPCTimeout = 500 * time.Millisecond
+	defaultRetry      = 50 * time.Millisecond
+)
+
+// Options configures a Cluster created via NewCluster.
+type Options struct {
+	// TTL is the lease duration a node grants a key for. A crashed
+	// client's locks expire and become available again after TTL. Leases
+	// are not renewed while held, so TTL must comfortably exceed the
+	// longest critical section a caller will run while holding a key.
+	TTL time.Duration
+	// RPCTimeout bounds how long Cluster waits for a single node to
+	// respond to a Lock or Unlock call.
+	RPCTimeout time.Duration
+	// RetryInterval is how long Lock waits between quorum attempts.
+	RetryInterval time.Duration
+}
+
+// Option configures a Cluster created via NewCluster.
+type Option func(*Options)
+
+// WithTTL sets the lease duration granted per key. Default 10s.
+func WithTTL(d time.Duration) Option { return func(o *Options) { o.TTL = d } }
+
+// WithRPCTimeout bounds how long a single node has to answer. Default 500ms.
+func WithRPCTimeout(d time.Duration) Option { return func(o *Options) { o.RPCTimeout = d } }
+
+// WithRetryInterval sets the delay between quorum attempts in Lock.
+// Default 50ms.
+func WithRetryInterval(d time.Duration) Option { return func(o *Options) { o.RetryInterval = d } }
+
+// Cluster is a kemu.Locker backed by a quorum of remote NodeServers. A call
+// to Lock sends a grant request to every node in parallel and succeeds
+// once ⌈N/2⌉+1 nodes grant it within RPCTimeout; on failure it releases
+// whatever partial grants it did get. Each key is also serialized
+// in-process via an embedded kemu.Mutex, so Cluster is safe to share
+// across goroutines the same way kemu.Mutex is.
+type Cluster struct {
+	opts   Options
+	addrs  []string
+	quorum int
+	local  *kemu.Mutex[string]
+
+	mu     sync.Mutex
+	leases map[string]string // key -> requestID currently held by this process
+}
+
+// NewCluster returns a Locker that coordinates the given nodes' addresses
+// (host:port, each expected to run a NodeServer registered via Serve).
+func NewCluster(nodes []string, opts ...Option) kemu.Locker {
+	if len(nodes) == 0 {
+		panic("distributed: NewCluster requires at least one node")
+	}
+
+	o := Options{
+		TTL:           defaultTTL,
+		RPCTimeout:    defaultRPCTimeout,
+		RetryInterval: defaultRetry,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	addrs := make([]string, len(nodes))
+	copy(addrs, nodes)
+
+	return &Cluster{
+		opts:   o,
+		addrs:  addrs,
+		quorum: len(addrs)/2 + 1,
+		local:  kemu.New[string](),
+		leases: make(map[string]string),
+	}
+}
+
+// Lock blocks until key is granted by a quorum of nodes.
+func (c *Cluster) Lock(key string) {
+	c.local.Lock(key)
+
+	// Each attempt uses its own requestID: acquireQuorum fires an
+	// un-awaited Unlock for a failed attempt's partial grants, and
+	// reusing the same requestID on retry could race that stale Unlock
+	// against the next attempt's own (idempotent) re-Lock of the key.
+	for {
+		requestID := c.newRequestID()
+		if c.acquireQuorum(key, requestID) {
+			c.setLease(key, requestID)
+			return
+		}
+		time.Sleep(c.opts.RetryInterval)
+	}
+}
+
+// TryLock attempts to acquire key without retrying, returning false if a
+// quorum wasn't reached within RPCTimeout.
+func (c *Cluster) TryLock(key string) bool {
+	if !c.local.TryLock(key) {
+		return false
+	}
+
+	requestID := c.newRequestID()
+	if c.acquireQuorum(key, requestID) {
+		c.setLease(key, requestID)
+		return true
+	}
+
+	c.local.Unlock(key)
+	return false
+}
+
+// Unlock releases key, notifying every node that granted it.
+func (c *Cluster) Unlock(key string) {
+	requestID := c.takeLease(key)
+	if requestID != "" {
+		c.releaseQuorum(key, requestID)
+	}
+	c.local.Unlock(key)
+}
+
+// Locked reports whether this process currently holds key.
+func (c *Cluster) Locked(key string) bool {
+	return c.local.Locked(key)
+}
+
+func (c *Cluster) setLease(key, requestID string) {
+	c.mu.Lock()
+	c.leases[key] = requestID
+	c.mu.Unlock()
+}
+
+func (c *Cluster) takeLease(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	requestID := c.leases[key]
+	delete(c.leases, key)
+	return requestID
+}
+
+// acquireQuorum asks every node to grant key to requestID in parallel, and
+// reports whether at least a quorum granted within RPCTimeout. On failure
+// it releases whatever grants it did get, so a retry doesn't leave stale
+// partial state lying around until the lease expires.
+func (c *Cluster) acquireQuorum(key, requestID string) bool {
+	type result struct {
+		addr    string
+		granted bool
+	}
+
+	results := make(chan result, len(c.addrs))
+	for _, addr := range c.addrs {
+		addr := addr
+		go func() {
+			results <- result{addr: addr, granted: c.callLock(addr, key, requestID)}
+		}()
+	}
+
+	var granted []string
+	for range c.addrs {
+		r := <-results
+		if r.granted {
+			granted = append(granted, r.addr)
+		}
+	}
+
+	if len(granted) >= c.quorum {
+		return true
+	}
+
+	for _, addr := range granted {
+		go c.callUnlock(addr, key, requestID)
+	}
+	return false
+}
+
+// releaseQuorum sends UNLOCK to every node in parallel, best effort: nodes
+// that don't respond will still expire the lease via TTL.
+func (c *Cluster) releaseQuorum(key, requestID string) {
+	var wg sync.WaitGroup
+	for _, addr := range c.addrs {
+		addr := addr
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.callUnlock(addr, key, requestID)
+		}()
+	}
+	wg.Wait()
+}
+
+// callLock and callUnlock each spend the whole of RPCTimeout across both
+// dialing and waiting for the reply, rather than applying it twice, so a
+// slow-to-accept node can't stall a quorum round for up to 2x RPCTimeout.
+
+func (c *Cluster) callLock(addr, key, requestID string) bool {
+	deadline := time.Now().Add(c.opts.RPCTimeout)
+
+	client, err := c.dial(addr, c.opts.RPCTimeout)
+	if err != nil {
+		return false
+	}
+	defer client.Close()
+
+	args := &LockArgs{Key: key, RequestID: requestID, TTL: c.opts.TTL}
+	reply := &LockReply{}
+	call := client.Go("NodeServer.Lock", args, reply, nil)
+
+	select {
+	case res := <-call.Done:
+		return res.Error == nil && reply.Granted
+	case <-time.After(time.Until(deadline)):
+		return false
+	}
+}
+
+func (c *Cluster) callUnlock(addr, key, requestID string) {
+	deadline := time.Now().Add(c.opts.RPCTimeout)
+
+	client, err := c.dial(addr, c.opts.RPCTimeout)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	args := &UnlockArgs{Key: key, RequestID: requestID}
+	reply := &UnlockReply{}
+	call := client.Go("NodeServer.Unlock", args, reply, nil)
+
+	select {
+	case <-call.Done:
+	case <-time.After(time.Until(deadline)):
+	}
+}
+
+func (c *Cluster) dial(addr string, timeout time.Duration) (*rpc.Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.NewClient(conn), nil
+}
+
+// newRequestID returns a unique id identifying one acquisition attempt, so
+// a node can tell apart this Cluster's successive Lock calls for the same
+// key (and so retries of the same attempt stay idempotent, see
+// NodeServer.Lock).
+func (c *Cluster) newRequestID() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
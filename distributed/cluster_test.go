@@ -0,0 +1,86 @@
+package distributed
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+)
+
+// startNode runs a NodeServer on an OS-assigned loopback port and returns
+// its address, stopping it when the test ends.
+func startNode(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("NodeServer", NewNodeServer()); err != nil {
+		t.Fatalf("failed to register NodeServer: %v", err)
+	}
+	go srv.Accept(l)
+
+	return l.Addr().String()
+}
+
+func TestCluster_LockUnlock(t *testing.T) {
+	addrs := []string{startNode(t), startNode(t), startNode(t)}
+
+	c := NewCluster(addrs, WithRPCTimeout(200*time.Millisecond))
+
+	key := "testKey"
+	c.Lock(key)
+	if !c.Locked(key) {
+		t.Errorf("Expected key %s to be locked", key)
+	}
+	c.Unlock(key)
+
+	if c.Locked(key) {
+		t.Errorf("Expected key %s to be unlocked after unlock", key)
+	}
+}
+
+func TestCluster_TryLockExcludesConcurrentHolders(t *testing.T) {
+	addrs := []string{startNode(t), startNode(t), startNode(t)}
+
+	a := NewCluster(addrs, WithRPCTimeout(200*time.Millisecond))
+	b := NewCluster(addrs, WithRPCTimeout(200*time.Millisecond))
+
+	key := "testKey"
+	if !a.TryLock(key) {
+		t.Fatalf("Expected first TryLock to succeed")
+	}
+	if b.TryLock(key) {
+		t.Errorf("Expected second cluster's TryLock to fail while a holds the quorum")
+	}
+
+	a.Unlock(key)
+
+	if !b.TryLock(key) {
+		t.Errorf("Expected TryLock to succeed once a released the key")
+	}
+	b.Unlock(key)
+}
+
+func TestCluster_ToleratesMinorityNodeFailure(t *testing.T) {
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	downAddr := down.Addr().String()
+	_ = down.Close() // nothing will answer on this address
+
+	addrs := []string{startNode(t), startNode(t), downAddr}
+
+	c := NewCluster(addrs, WithRPCTimeout(200*time.Millisecond))
+
+	key := "testKey"
+	if !c.TryLock(key) {
+		t.Fatalf("Expected TryLock to still reach a quorum with one node down")
+	}
+	c.Unlock(key)
+}
@@ -0,0 +1,106 @@
+// Package distributed implements kemu.Locker over a cluster of remote
+// nodes, using a Redlock-style quorum protocol: Lock sends a grant request
+// to every node in parallel and succeeds once a majority grant it within a
+// lease. This mirrors the approach minio/dsync takes for distributed locks
+// built on top of a simple per-node lock server.
+package distributed
+
+import (
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// LockArgs is the request for NodeServer.Lock.
+type LockArgs struct {
+	Key       string
+	RequestID string
+	TTL       time.Duration
+}
+
+// LockReply is the response for NodeServer.Lock.
+type LockReply struct {
+	Granted bool
+}
+
+// UnlockArgs is the request for NodeServer.Unlock.
+type UnlockArgs struct {
+	Key       string
+	RequestID string
+}
+
+// UnlockReply is the response for NodeServer.Unlock.
+type UnlockReply struct{}
+
+// grant is a single node's bookkeeping for one held key.
+type grant struct {
+	requestID string
+	expiresAt time.Time
+}
+
+// NodeServer is the per-node lock server a Cluster talks to. Each node
+// runs its own NodeServer and only grants a key if it isn't currently held
+// by a different requestID whose lease hasn't expired, so a crashed
+// client's locks eventually time out instead of wedging the key forever.
+type NodeServer struct {
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+// NewNodeServer creates a NodeServer ready to be registered with net/rpc.
+func NewNodeServer() *NodeServer {
+	return &NodeServer{
+		grants: make(map[string]grant),
+	}
+}
+
+// Lock grants key to RequestID if it is free or already expired, or if
+// RequestID already holds it (making grants idempotent under retry).
+func (s *NodeServer) Lock(args *LockArgs, reply *LockReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, held := s.grants[args.Key]
+	if held && g.requestID != args.RequestID && time.Now().Before(g.expiresAt) {
+		reply.Granted = false
+		return nil
+	}
+
+	s.grants[args.Key] = grant{
+		requestID: args.RequestID,
+		expiresAt: time.Now().Add(args.TTL),
+	}
+	reply.Granted = true
+	return nil
+}
+
+// Unlock releases key if it is currently held by RequestID. Releasing a
+// key this node never granted to RequestID, or that already expired and
+// was reassigned, is a no-op.
+func (s *NodeServer) Unlock(args *UnlockArgs, reply *UnlockReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if g, held := s.grants[args.Key]; held && g.requestID == args.RequestID {
+		delete(s.grants, args.Key)
+	}
+	return nil
+}
+
+// Serve registers s under its RPC name and serves it on addr until the
+// listener is closed or accepting fails. It is intended to be run in its
+// own goroutine, one per cluster node.
+func Serve(addr string, s *NodeServer) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName("NodeServer", s); err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server.Accept(l)
+	return nil
+}
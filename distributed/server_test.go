@@ -0,0 +1,89 @@
+package distributed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNodeServer_LockUnlock(t *testing.T) {
+	s := NewNodeServer()
+
+	var reply LockReply
+	if err := s.Lock(&LockArgs{Key: "k", RequestID: "a", TTL: time.Second}, &reply); err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if !reply.Granted {
+		t.Errorf("Expected first Lock to be granted")
+	}
+
+	reply = LockReply{}
+	if err := s.Lock(&LockArgs{Key: "k", RequestID: "b", TTL: time.Second}, &reply); err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if reply.Granted {
+		t.Errorf("Expected Lock from a different requestID to be refused while held")
+	}
+
+	var unlockReply UnlockReply
+	if err := s.Unlock(&UnlockArgs{Key: "k", RequestID: "a"}, &unlockReply); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+
+	reply = LockReply{}
+	if err := s.Lock(&LockArgs{Key: "k", RequestID: "b", TTL: time.Second}, &reply); err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if !reply.Granted {
+		t.Errorf("Expected Lock to succeed for key %q after release", "k")
+	}
+}
+
+func TestNodeServer_LockIdempotentForSameRequestID(t *testing.T) {
+	s := NewNodeServer()
+
+	var reply LockReply
+	_ = s.Lock(&LockArgs{Key: "k", RequestID: "a", TTL: time.Second}, &reply)
+
+	reply = LockReply{}
+	if err := s.Lock(&LockArgs{Key: "k", RequestID: "a", TTL: time.Second}, &reply); err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if !reply.Granted {
+		t.Errorf("Expected re-acquiring the same key with the same requestID to succeed")
+	}
+}
+
+func TestNodeServer_LeaseExpires(t *testing.T) {
+	s := NewNodeServer()
+
+	var reply LockReply
+	_ = s.Lock(&LockArgs{Key: "k", RequestID: "a", TTL: 10 * time.Millisecond}, &reply)
+
+	time.Sleep(30 * time.Millisecond)
+
+	reply = LockReply{}
+	if err := s.Lock(&LockArgs{Key: "k", RequestID: "b", TTL: time.Second}, &reply); err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if !reply.Granted {
+		t.Errorf("Expected Lock to succeed once the prior lease expired")
+	}
+}
+
+func TestNodeServer_UnlockWrongRequestIDIsNoop(t *testing.T) {
+	s := NewNodeServer()
+
+	var reply LockReply
+	_ = s.Lock(&LockArgs{Key: "k", RequestID: "a", TTL: time.Second}, &reply)
+
+	var unlockReply UnlockReply
+	if err := s.Unlock(&UnlockArgs{Key: "k", RequestID: "b"}, &unlockReply); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+
+	reply = LockReply{}
+	_ = s.Lock(&LockArgs{Key: "k", RequestID: "b", TTL: time.Second}, &reply)
+	if reply.Granted {
+		t.Errorf("Expected key to still be held by requestID %q", "a")
+	}
+}
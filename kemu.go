@@ -0,0 +1,282 @@
+package kemu
+
+import (
+	"context"
+	"sync"
+)
+
+// Mutex provides a simple, coarse-grained keyed mutex (lockmap)
+// implementation. It is intended to be used by components that need to
+// lock on a specific key, but do not need to lock on multiple keys
+// simultaneously. The locks are not reentrant.
+//
+// K is the key type and must be comparable, e.g. string, int, or a
+// small struct used as a composite key.
+type Mutex[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*lockEntry
+	opts  *Options[K]
+}
+
+// lockEntry holds the FIFO of waiters for a single key. The waiter at
+// index 0 holds the lock; every other waiter blocks on its own channel
+// until it reaches the head and is signaled by the prior holder's
+// Unlock/LockContext cancellation.
+type lockEntry struct {
+	waiters []chan struct{}
+	det     *detection
+	// generation is bumped every time le is recycled by putLockEntry. A
+	// wait timer armed in onWaitStart captures it at arm time so that if
+	// the timer's callback runs late (time.Timer.Stop can't interrupt a
+	// callback that has already started) after le has been handed out
+	// again for an unrelated key, it can tell its holder snapshot is
+	// stale instead of reporting that key's holder as its own.
+	generation uint64
+}
+
+// lockEntryPool recycles lockEntry values across keys. Workloads that lock
+// many short-lived, unique keys would otherwise allocate and free one
+// lockEntry per key; pooling them cuts that churn.
+var lockEntryPool = sync.Pool{
+	New: func() any { return &lockEntry{} },
+}
+
+func getLockEntry() *lockEntry {
+	return lockEntryPool.Get().(*lockEntry)
+}
+
+// putLockEntry resets le and returns it to the pool. Callers must only do
+// this once le has no waiters left, i.e. right before it is removed from
+// the locks map.
+func putLockEntry(le *lockEntry) {
+	le.waiters = le.waiters[:0]
+	le.det = nil
+	le.generation++
+	lockEntryPool.Put(le)
+}
+
+// StringMutex is a Mutex keyed on string. It is kept as an alias to the
+// generic Mutex so that code written against the pre-generics, string-only
+// version of this package keeps working unchanged.
+type StringMutex = Mutex[string]
+
+// New is a Keyed Mutex implementation with a simple, coarse-grained
+// locking strategy. It is intended to be used by components that need
+// to lock on a specific key, but do not need to lock on multiple keys
+// simultaneously. The locks are not reentrant.
+//
+// By default New adds no deadlock detection overhead. Pass WithHoldTimeout,
+// WithWaitTimeout and/or WithOnPotentialDeadlock to opt in; see Options.
+func New[K comparable](opts ...Option[K]) *Mutex[K] {
+	sl := &Mutex[K]{
+		locks: make(map[K]*lockEntry),
+	}
+	if len(opts) > 0 {
+		o := &Options[K]{}
+		for _, opt := range opts {
+			opt(o)
+		}
+		sl.opts = o
+	}
+	return sl
+}
+
+// Locked returns true if the key is currently locked, false otherwise
+func (km *Mutex[K]) Locked(key K) bool {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	le, exists := km.locks[key]
+	return exists && len(le.waiters) > 0
+}
+
+// TryLock returns true if the lock was acquired, false otherwise
+func (km *Mutex[K]) TryLock(key K) bool {
+	le, ok := km.tryEnqueue(key)
+	if !ok {
+		return false
+	}
+	km.onAcquire(key, le)
+	return true
+}
+
+// Lock acquires a lock on the key
+func (km *Mutex[K]) Lock(key K) {
+	ch, le, first := km.enqueue(key)
+	if first {
+		km.onAcquire(key, le)
+		return
+	}
+
+	stopWait := km.onWaitStart(key, le)
+	<-ch
+	stopWait()
+	km.onAcquire(key, le)
+}
+
+// LockContext acquires a lock on the key, or returns ctx.Err() if ctx is
+// done before the lock is acquired. Unlike Lock, this is cancellable: the
+// waiter removes itself from the key's wait queue on cancellation and, if
+// it had already been handed the lock in the interim, forwards it to the
+// next waiter so no grant is lost.
+func (km *Mutex[K]) LockContext(ctx context.Context, key K) error {
+	ch, le, first := km.enqueue(key)
+	if first {
+		km.onAcquire(key, le)
+		return nil
+	}
+
+	stopWait := km.onWaitStart(key, le)
+	select {
+	case <-ch:
+		stopWait()
+		km.onAcquire(key, le)
+		return nil
+	case <-ctx.Done():
+		stopWait()
+		km.cancelWait(key, ch)
+		return ctx.Err()
+	}
+}
+
+// LockFunc acquires a lock on the key and returns a closure that releases
+// it. Unlike Unlock, the closure is idempotent: calling it more than once
+// unlocks only on the first call. Since it captures the *lockEntry it
+// acquired directly, releasing through it skips the map lookup Unlock
+// needs to find that entry from key alone.
+func (km *Mutex[K]) LockFunc(key K) func() {
+	ch, le, first := km.enqueue(key)
+	if !first {
+		stopWait := km.onWaitStart(key, le)
+		<-ch
+		stopWait()
+	}
+	km.onAcquire(key, le)
+	return km.unlockFunc(key, le)
+}
+
+// TryLockFunc is the TryLock counterpart to LockFunc: it returns an
+// idempotent unlock closure and ok=true if the lock was acquired, or a nil
+// closure and ok=false otherwise.
+func (km *Mutex[K]) TryLockFunc(key K) (unlock func(), ok bool) {
+	le, ok := km.tryEnqueue(key)
+	if !ok {
+		return nil, false
+	}
+	km.onAcquire(key, le)
+	return km.unlockFunc(key, le), true
+}
+
+// tryEnqueue acquires key without blocking if it is free, reporting the
+// lockEntry it acquired and ok=true, or ok=false if it was already held.
+func (km *Mutex[K]) tryEnqueue(key K) (le *lockEntry, ok bool) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if exists && len(le.waiters) > 0 {
+		return nil, false
+	}
+	if !exists {
+		le = getLockEntry()
+		km.locks[key] = le
+	}
+	le.waiters = append(le.waiters, make(chan struct{}))
+	return le, true
+}
+
+// Unlock releases a lock on the key
+func (km *Mutex[K]) Unlock(key K) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if !exists || len(le.waiters) == 0 {
+		panic("unlock of unlocked lock")
+	}
+
+	km.unlockEntry(key, le)
+}
+
+// unlockEntry performs the actual release of le, acquired for key. Callers
+// must hold km.mu.
+func (km *Mutex[K]) unlockEntry(key K, le *lockEntry) {
+	km.onRelease(le)
+
+	le.waiters = le.waiters[1:]
+	if len(le.waiters) > 0 {
+		close(le.waiters[0])
+	} else {
+		delete(km.locks, key)
+		putLockEntry(le)
+	}
+}
+
+// unlockFunc returns an idempotent closure that unlocks le, acquired for
+// key, exactly once.
+func (km *Mutex[K]) unlockFunc(key K, le *lockEntry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			km.mu.Lock()
+			defer km.mu.Unlock()
+			km.unlockEntry(key, le)
+		})
+	}
+}
+
+// enqueue registers a new waiter for key and reports whether it acquired
+// the lock immediately (i.e. it was the only entry in the queue).
+func (km *Mutex[K]) enqueue(key K) (ch chan struct{}, le *lockEntry, first bool) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if !exists {
+		le = getLockEntry()
+		km.locks[key] = le
+	}
+	ch = make(chan struct{})
+	le.waiters = append(le.waiters, ch)
+	return ch, le, len(le.waiters) == 1
+}
+
+// cancelWait removes ch from key's wait queue. If ch had already been
+// closed (the waiter was granted the lock right as its context was
+// cancelled), the grant is forwarded to the new head of the queue so it
+// is not lost.
+func (km *Mutex[K]) cancelWait(key K, ch chan struct{}) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if !exists {
+		return
+	}
+
+	idx := -1
+	for i, w := range le.waiters {
+		if w == ch {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return
+	}
+
+	var granted bool
+	select {
+	case <-ch:
+		granted = true
+	default:
+	}
+
+	le.waiters = append(le.waiters[:idx], le.waiters[idx+1:]...)
+	if granted && idx == 0 && len(le.waiters) > 0 {
+		close(le.waiters[0])
+	}
+	if len(le.waiters) == 0 {
+		delete(km.locks, key)
+		putLockEntry(le)
+	}
+}
@@ -1,6 +1,7 @@
 package kemu
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"sync"
@@ -10,7 +11,7 @@ import (
 )
 
 func TestKeyedMutex_LockUnlock(t *testing.T) {
-	km := New()
+	km := New[string]()
 
 	key := "testKey"
 	km.Lock(key)
@@ -22,7 +23,7 @@ func TestKeyedMutex_LockUnlock(t *testing.T) {
 }
 
 func TestKeyedMutex_TryLocked(t *testing.T) {
-	km := New()
+	km := New[string]()
 
 	key := "testKey"
 	if !km.TryLock(key) {
@@ -40,7 +41,7 @@ func TestKeyedMutex_TryLocked(t *testing.T) {
 }
 
 func TestKeyedMutex_ConcurrentAccess(t *testing.T) {
-	km := New()
+	km := New[string]()
 	key := "testKey"
 	var wg sync.WaitGroup
 
@@ -65,7 +66,7 @@ func TestKeyedMutex_ConcurrentAccess(t *testing.T) {
 }
 
 func TestKeyedMutex_Locked(t *testing.T) {
-	km := New()
+	km := New[string]()
 
 	key := "testKey"
 	if km.Locked(key) {
@@ -84,7 +85,7 @@ func TestKeyedMutex_Locked(t *testing.T) {
 }
 
 func TestKeyedMutex_HighConcurrency(t *testing.T) {
-	km := New()
+	km := New[string]()
 	const numKeys = 100
 	const numGoroutines = 1000
 	const iterations = 50
@@ -134,7 +135,7 @@ func TestKeyedMutex_HighConcurrency(t *testing.T) {
 }
 
 func TestKeyedMutex_DeadlockDetection(t *testing.T) {
-	km := New()
+	km := New[string]()
 	key := "testKey"
 
 	// Lock once
@@ -165,7 +166,7 @@ func TestKeyedMutex_DeadlockDetection(t *testing.T) {
 }
 
 func TestKeyedMutex_StressTest(t *testing.T) {
-	km := New()
+	km := New[string]()
 	const numKeys = 10
 	const numOps = 100000
 
@@ -225,7 +226,7 @@ func TestKeyedMutex_StressTest(t *testing.T) {
 }
 
 func TestKeyedMutex_UnlockNonExistentKey(t *testing.T) {
-	km := New()
+	km := New[string]()
 	key := "nonExistentKey"
 
 	// Test that unlocking a non-existent key panics
@@ -239,7 +240,7 @@ func TestKeyedMutex_UnlockNonExistentKey(t *testing.T) {
 }
 
 func TestKeyedMutex_LockedAfterPanic(t *testing.T) {
-	km := New()
+	km := New[string]()
 	key := "testKey"
 
 	// Function that will lock, panic, and recover
@@ -262,7 +263,7 @@ func TestKeyedMutex_LockedAfterPanic(t *testing.T) {
 }
 
 func TestKeyedMutex_ConcurrentDifferentKeys(t *testing.T) {
-	km := New()
+	km := New[string]()
 	const numKeys = 100
 
 	// Should be able to lock different keys concurrently
@@ -293,7 +294,7 @@ func TestKeyedMutex_ConcurrentDifferentKeys(t *testing.T) {
 }
 
 func TestKeyedMutex_MemoryLeak(t *testing.T) {
-	km := New()
+	km := New[string]()
 	const numKeys = 10000
 
 	// Lock and unlock many keys
@@ -310,3 +311,237 @@ func TestKeyedMutex_MemoryLeak(t *testing.T) {
 		t.Errorf("Expected empty map after all locks released, but found %d entries", mapSize)
 	}
 }
+
+// recordID is a non-string key type used to exercise Mutex's genericity.
+type recordID struct {
+	shard int
+	id    int
+}
+
+func TestKeyedMutex_IntKey(t *testing.T) {
+	km := New[int]()
+
+	key := 42
+	km.Lock(key)
+	if !km.Locked(key) {
+		t.Errorf("Expected key %d to be locked", key)
+	}
+	km.Unlock(key)
+
+	if km.Locked(key) {
+		t.Errorf("Expected key %d to be unlocked after unlock", key)
+	}
+}
+
+func TestKeyedMutex_StructKey(t *testing.T) {
+	km := New[recordID]()
+
+	key := recordID{shard: 1, id: 7}
+	other := recordID{shard: 1, id: 8}
+
+	if !km.TryLock(key) {
+		t.Errorf("Expected TryLock to succeed for key %v", key)
+	}
+	if km.TryLock(key) {
+		t.Errorf("Expected TryLock to fail for key %v while held", key)
+	}
+	if !km.TryLock(other) {
+		t.Errorf("Expected TryLock to succeed for distinct key %v", other)
+	}
+
+	km.Unlock(key)
+	km.Unlock(other)
+}
+
+func TestKeyedMutex_LockContext_Acquires(t *testing.T) {
+	km := New[string]()
+	key := "testKey"
+
+	ctx := context.Background()
+	if err := km.LockContext(ctx, key); err != nil {
+		t.Fatalf("LockContext returned unexpected error: %v", err)
+	}
+	if !km.Locked(key) {
+		t.Errorf("Expected key %s to be locked", key)
+	}
+	km.Unlock(key)
+}
+
+func TestKeyedMutex_LockContext_CancelWhileWaiting(t *testing.T) {
+	km := New[string]()
+	key := "testKey"
+
+	km.Lock(key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := km.LockContext(ctx, key)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	km.Unlock(key)
+
+	// The key must still be usable after a cancelled waiter.
+	if !km.TryLock(key) {
+		t.Errorf("Expected TryLock to succeed for key %s after cancellation", key)
+	}
+	km.Unlock(key)
+}
+
+func TestKeyedMutex_DetectionDisabledByDefault(t *testing.T) {
+	km := New[string]()
+	if km.opts != nil {
+		t.Errorf("Expected detection to be disabled when no options are passed to New")
+	}
+}
+
+func TestKeyedMutex_OnPotentialDeadlock_Hold(t *testing.T) {
+	reports := make(chan DeadlockInfo[string], 1)
+	km := New[string](
+		WithHoldTimeout[string](10*time.Millisecond),
+		WithOnPotentialDeadlock[string](func(info DeadlockInfo[string]) {
+			reports <- info
+		}),
+	)
+
+	key := "testKey"
+	km.Lock(key)
+	defer km.Unlock(key)
+
+	select {
+	case info := <-reports:
+		if info.Kind != DeadlockKindHold {
+			t.Errorf("Expected DeadlockKindHold, got %v", info.Kind)
+		}
+		if info.Key != key {
+			t.Errorf("Expected key %q, got %q", key, info.Key)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected OnPotentialDeadlock to fire for a long-held lock")
+	}
+}
+
+func TestKeyedMutex_OnPotentialDeadlock_Wait(t *testing.T) {
+	reports := make(chan DeadlockInfo[string], 1)
+	km := New[string](
+		WithWaitTimeout[string](10*time.Millisecond),
+		WithOnPotentialDeadlock[string](func(info DeadlockInfo[string]) {
+			reports <- info
+		}),
+	)
+
+	key := "testKey"
+	km.Lock(key)
+
+	go func() {
+		km.Lock(key)
+		km.Unlock(key)
+	}()
+
+	select {
+	case info := <-reports:
+		if info.Kind != DeadlockKindWait {
+			t.Errorf("Expected DeadlockKindWait, got %v", info.Kind)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected OnPotentialDeadlock to fire for a long-waiting caller")
+	}
+
+	km.Unlock(key)
+}
+
+func TestKeyedMutex_LockFunc(t *testing.T) {
+	km := New[string]()
+	key := "testKey"
+
+	unlock := km.LockFunc(key)
+	if !km.Locked(key) {
+		t.Errorf("Expected key %s to be locked", key)
+	}
+
+	unlock()
+	if km.Locked(key) {
+		t.Errorf("Expected key %s to be unlocked", key)
+	}
+
+	// Idempotent: calling unlock again must not panic or double-release.
+	unlock()
+
+	if !km.TryLock(key) {
+		t.Errorf("Expected TryLock to succeed for key %s after LockFunc release", key)
+	}
+	km.Unlock(key)
+}
+
+func TestKeyedMutex_TryLockFunc(t *testing.T) {
+	km := New[string]()
+	key := "testKey"
+
+	unlock, ok := km.TryLockFunc(key)
+	if !ok {
+		t.Fatalf("Expected TryLockFunc to succeed for key %s", key)
+	}
+
+	if _, ok := km.TryLockFunc(key); ok {
+		t.Errorf("Expected TryLockFunc to fail for key %s while held", key)
+	}
+
+	unlock()
+	unlock() // idempotent
+
+	if km.Locked(key) {
+		t.Errorf("Expected key %s to be unlocked", key)
+	}
+}
+
+func TestKeyedMutex_LockContext_HandsOffToNextWaiter(t *testing.T) {
+	km := New[string]()
+	key := "testKey"
+
+	km.Lock(key)
+
+	// waiterA will be cancelled; waiterB should still get the lock once
+	// the holder unlocks.
+	ctxA, cancelA := context.WithCancel(context.Background())
+	errA := make(chan error, 1)
+	go func() {
+		errA <- km.LockContext(ctxA, key)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancelA()
+	if err := <-errA; err != context.Canceled {
+		t.Errorf("Expected context.Canceled for waiterA, got %v", err)
+	}
+
+	doneB := make(chan error, 1)
+	go func() {
+		doneB <- km.LockContext(context.Background(), key)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	km.Unlock(key)
+
+	select {
+	case err := <-doneB:
+		if err != nil {
+			t.Errorf("waiterB expected to acquire the lock, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("waiterB never acquired the lock")
+	}
+
+	km.Unlock(key)
+}
+
+// BenchmarkChurn locks and unlocks a fresh, unique key on every iteration,
+// the pattern TestKeyedMutex_MemoryLeak exercises for correctness. It
+// demonstrates the allocation savings from pooling lockEntry values.
+func BenchmarkChurn(b *testing.B) {
+	km := New[int]()
+	for i := 0; i < b.N; i++ {
+		km.Lock(i)
+		km.Unlock(i)
+	}
+}
@@ -0,0 +1,16 @@
+package kemu
+
+// Locker is the interface satisfied by a string-keyed keyed mutex,
+// in-process or distributed. StringMutex implements it directly; the
+// kemu/distributed package implements it over a cluster of remote nodes
+// using the same semantics, so code written against Locker can move from
+// single-process locking to distributed coordination without changing its
+// locking calls.
+type Locker interface {
+	Lock(key string)
+	Unlock(key string)
+	TryLock(key string) bool
+	Locked(key string) bool
+}
+
+var _ Locker = (*StringMutex)(nil)
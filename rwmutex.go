@@ -0,0 +1,277 @@
+package kemu
+
+import (
+	"context"
+	"sync"
+)
+
+// RWMutex is a keyed mutex that, unlike Mutex, distinguishes between
+// shared (read) and exclusive (write) acquisition per key. Any number of
+// readers may hold a given key at once, but a writer excludes both
+// readers and other writers. This suits callers like per-object caches
+// or per-file processing, where multiple readers of the same key should
+// be allowed to proceed in parallel.
+type RWMutex[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*rwLockEntry
+}
+
+// rwLockEntry tracks the state of a single key: how many readers and
+// whether a writer currently hold it, plus the FIFO of waiters still
+// queued behind them. Waiters are granted in order, and a reader only
+// jumps straight to active if the queue is empty, so a writer already
+// queued isn't starved by a steady stream of new readers.
+type rwLockEntry struct {
+	waiters       []*rwWaiter
+	activeReaders int
+	activeWriter  bool
+}
+
+// rwWaiter is a single queued Lock/RLock call. ch is closed once the
+// waiter is granted; write distinguishes an exclusive waiter from a
+// shared one.
+type rwWaiter struct {
+	ch    chan struct{}
+	write bool
+}
+
+// NewRW is a Keyed RWMutex implementation. It is intended to be used by
+// components that need to lock on a specific key with either shared or
+// exclusive semantics, but do not need to lock on multiple keys
+// simultaneously. The locks are not reentrant.
+func NewRW[K comparable]() *RWMutex[K] {
+	return &RWMutex[K]{
+		locks: make(map[K]*rwLockEntry),
+	}
+}
+
+// Locked returns true if the key is currently held, for reading or
+// writing, false otherwise.
+func (km *RWMutex[K]) Locked(key K) bool {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	le, exists := km.locks[key]
+	return exists && (le.activeReaders > 0 || le.activeWriter)
+}
+
+// Lock acquires an exclusive lock on the key.
+func (km *RWMutex[K]) Lock(key K) {
+	w, _, granted := km.enqueueWrite(key)
+	if !granted {
+		<-w.ch
+	}
+}
+
+// LockContext acquires an exclusive lock on the key, or returns ctx.Err()
+// if ctx is done first. Like Mutex.LockContext, this removes the waiter
+// from the key's queue on cancellation and, if it had already been
+// granted in the interim, hands the lock off to the next waiter so no
+// grant is lost.
+func (km *RWMutex[K]) LockContext(ctx context.Context, key K) error {
+	w, le, granted := km.enqueueWrite(key)
+	if granted {
+		return nil
+	}
+
+	select {
+	case <-w.ch:
+		return nil
+	case <-ctx.Done():
+		km.cancelWait(key, le, w)
+		return ctx.Err()
+	}
+}
+
+// TryLock returns true if an exclusive lock was acquired, false otherwise.
+func (km *RWMutex[K]) TryLock(key K) bool {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if exists && (le.activeReaders > 0 || le.activeWriter || len(le.waiters) > 0) {
+		return false
+	}
+	if !exists {
+		le = &rwLockEntry{}
+		km.locks[key] = le
+	}
+	le.activeWriter = true
+	return true
+}
+
+// Unlock releases an exclusive lock on the key.
+func (km *RWMutex[K]) Unlock(key K) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if !exists || !le.activeWriter {
+		panic("unlock of unlocked lock")
+	}
+	le.activeWriter = false
+	km.grantNext(key, le)
+}
+
+// RLock acquires a shared lock on the key.
+func (km *RWMutex[K]) RLock(key K) {
+	w, _, granted := km.enqueueRead(key)
+	if !granted {
+		<-w.ch
+	}
+}
+
+// RLockContext acquires a shared lock on the key, or returns ctx.Err() if
+// ctx is done first. See LockContext for the cancellation semantics,
+// which apply identically here.
+func (km *RWMutex[K]) RLockContext(ctx context.Context, key K) error {
+	w, le, granted := km.enqueueRead(key)
+	if granted {
+		return nil
+	}
+
+	select {
+	case <-w.ch:
+		return nil
+	case <-ctx.Done():
+		km.cancelWait(key, le, w)
+		return ctx.Err()
+	}
+}
+
+// TryRLock returns true if a shared lock was acquired, false otherwise.
+func (km *RWMutex[K]) TryRLock(key K) bool {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if exists && (le.activeWriter || len(le.waiters) > 0) {
+		return false
+	}
+	if !exists {
+		le = &rwLockEntry{}
+		km.locks[key] = le
+	}
+	le.activeReaders++
+	return true
+}
+
+// RUnlock releases a shared lock on the key.
+func (km *RWMutex[K]) RUnlock(key K) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if !exists || le.activeReaders == 0 {
+		panic("runlock of unlocked lock")
+	}
+	le.activeReaders--
+	km.grantNext(key, le)
+}
+
+// enqueueWrite registers a new exclusive waiter for key, granting it
+// immediately if the key is completely idle. When granted==true, w is
+// nil since there's nothing left to wait on.
+func (km *RWMutex[K]) enqueueWrite(key K) (w *rwWaiter, le *rwLockEntry, granted bool) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if !exists {
+		le = &rwLockEntry{}
+		km.locks[key] = le
+	}
+	if len(le.waiters) == 0 && le.activeReaders == 0 && !le.activeWriter {
+		le.activeWriter = true
+		return nil, le, true
+	}
+
+	w = &rwWaiter{ch: make(chan struct{}), write: true}
+	le.waiters = append(le.waiters, w)
+	return w, le, false
+}
+
+// enqueueRead registers a new shared waiter for key, granting it
+// immediately if there is no active writer and no one already queued
+// (so a reader can't jump ahead of a writer that's waiting its turn).
+// When granted==true, w is nil since there's nothing left to wait on.
+func (km *RWMutex[K]) enqueueRead(key K) (w *rwWaiter, le *rwLockEntry, granted bool) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	le, exists := km.locks[key]
+	if !exists {
+		le = &rwLockEntry{}
+		km.locks[key] = le
+	}
+	if len(le.waiters) == 0 && !le.activeWriter {
+		le.activeReaders++
+		return nil, le, true
+	}
+
+	w = &rwWaiter{ch: make(chan struct{})}
+	le.waiters = append(le.waiters, w)
+	return w, le, false
+}
+
+// grantNext grants as many queued waiters as the current state allows:
+// every leading reader up to the next writer (or until a writer can't be
+// granted because readers/a writer are still active), one writer at
+// most. Callers must hold km.mu and have already applied their own
+// release to le's active counters. If the key ends up completely idle
+// with nothing queued, its entry is removed from the map.
+func (km *RWMutex[K]) grantNext(key K, le *rwLockEntry) {
+	for len(le.waiters) > 0 {
+		w := le.waiters[0]
+		if w.write {
+			if le.activeReaders > 0 || le.activeWriter {
+				break
+			}
+			le.waiters = le.waiters[1:]
+			le.activeWriter = true
+			close(w.ch)
+			break
+		}
+
+		if le.activeWriter {
+			break
+		}
+		le.waiters = le.waiters[1:]
+		le.activeReaders++
+		close(w.ch)
+	}
+
+	if le.activeReaders == 0 && !le.activeWriter && len(le.waiters) == 0 {
+		delete(km.locks, key)
+	}
+}
+
+// cancelWait removes w from key's queue. le's state (waiters, active
+// counters) only ever changes under km.mu, and w is only ever popped
+// from le.waiters by grantNext at the moment it grants it, so the one
+// race to account for is grantNext granting w concurrently with its own
+// context being cancelled. If that happened, w is no longer in the
+// queue to remove; release what was granted on the abandoning waiter's
+// behalf and hand it off via grantNext instead of leaking it.
+func (km *RWMutex[K]) cancelWait(key K, le *rwLockEntry, w *rwWaiter) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	idx := -1
+	for i, x := range le.waiters {
+		if x == w {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		if w.write {
+			le.activeWriter = false
+		} else {
+			le.activeReaders--
+		}
+		km.grantNext(key, le)
+		return
+	}
+
+	le.waiters = append(le.waiters[:idx], le.waiters[idx+1:]...)
+}
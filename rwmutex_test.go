@@ -0,0 +1,237 @@
+package kemu
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRWMutex_Locked(t *testing.T) {
+	km := NewRW[string]()
+	key := "testKey"
+
+	if km.Locked(key) {
+		t.Errorf("Expected key %s to be initially unlocked", key)
+	}
+
+	km.Lock(key)
+	if !km.Locked(key) {
+		t.Errorf("Expected key %s to be locked", key)
+	}
+	km.Unlock(key)
+
+	if km.Locked(key) {
+		t.Errorf("Expected key %s to be unlocked after unlock", key)
+	}
+}
+
+func TestRWMutex_TryLockExcludesReaders(t *testing.T) {
+	km := NewRW[string]()
+	key := "testKey"
+
+	if !km.TryRLock(key) {
+		t.Errorf("Expected TryRLock to succeed for key %s", key)
+	}
+	if km.TryLock(key) {
+		t.Errorf("Expected TryLock to fail while a reader holds key %s", key)
+	}
+	km.RUnlock(key)
+
+	if !km.TryLock(key) {
+		t.Errorf("Expected TryLock to succeed for key %s after reader released", key)
+	}
+	km.Unlock(key)
+}
+
+func TestRWMutex_TryRLockExcludedByWriter(t *testing.T) {
+	km := NewRW[string]()
+	key := "testKey"
+
+	if !km.TryLock(key) {
+		t.Errorf("Expected TryLock to succeed for key %s", key)
+	}
+	if km.TryRLock(key) {
+		t.Errorf("Expected TryRLock to fail while a writer holds key %s", key)
+	}
+	km.Unlock(key)
+
+	if !km.TryRLock(key) {
+		t.Errorf("Expected TryRLock to succeed for key %s after writer released", key)
+	}
+	km.RUnlock(key)
+}
+
+func TestRWMutex_ConcurrentReaders(t *testing.T) {
+	km := NewRW[string]()
+	key := "testKey"
+
+	const numReaders = 50
+	var inFlight int32
+	var maxInFlight int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < numReaders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			km.RLock(key)
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			km.RUnlock(key)
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight < 2 {
+		t.Errorf("Expected more than one reader in flight concurrently, got max %d", maxInFlight)
+	}
+	if km.Locked(key) {
+		t.Errorf("Expected key %s to be unlocked once all readers released", key)
+	}
+}
+
+func TestRWMutex_WriterExcludesReaders(t *testing.T) {
+	km := NewRW[string]()
+	key := "testKey"
+
+	km.Lock(key)
+
+	done := make(chan bool)
+	go func() {
+		km.RLock(key)
+		km.RUnlock(key)
+		done <- true
+	}()
+
+	select {
+	case <-done:
+		t.Errorf("RLock acquired while writer held the key")
+	case <-time.After(20 * time.Millisecond):
+		// expected: reader is blocked
+	}
+
+	km.Unlock(key)
+	<-done
+}
+
+func TestRWMutex_LockContext_CancelledWhileWaiting(t *testing.T) {
+	km := NewRW[string]()
+	key := "testKey"
+
+	km.Lock(key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := km.LockContext(ctx, key)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	km.Unlock(key)
+}
+
+func TestRWMutex_LockContext_CancelDoesNotLeakHold(t *testing.T) {
+	km := NewRW[string]()
+	key := "testKey"
+
+	km.Lock(key)
+
+	// waiterA will be cancelled; waiterB should still get the lock once
+	// the holder unlocks, and the key must not appear locked once both
+	// the holder and waiterA are done with it.
+	ctxA, cancelA := context.WithCancel(context.Background())
+	errA := make(chan error, 1)
+	go func() {
+		errA <- km.LockContext(ctxA, key)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancelA()
+	if err := <-errA; err != context.Canceled {
+		t.Errorf("Expected context.Canceled for waiterA, got %v", err)
+	}
+
+	doneB := make(chan error, 1)
+	go func() {
+		doneB <- km.LockContext(context.Background(), key)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	km.Unlock(key)
+
+	select {
+	case err := <-doneB:
+		if err != nil {
+			t.Errorf("waiterB expected to acquire the lock, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("waiterB never acquired the lock")
+	}
+
+	km.Unlock(key)
+
+	// Give a leaked background goroutine (the bug this test guards
+	// against) a chance to phantom-acquire the key before checking.
+	time.Sleep(10 * time.Millisecond)
+	if km.Locked(key) {
+		t.Errorf("Expected key %s to be unlocked once the real holder and waiterB released it", key)
+	}
+}
+
+func TestRWMutex_RLockContext_CancelDoesNotLeakHold(t *testing.T) {
+	km := NewRW[string]()
+	key := "testKey"
+
+	km.Lock(key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- km.RLockContext(ctx, key)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-errCh; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	km.Unlock(key)
+
+	time.Sleep(10 * time.Millisecond)
+	if km.Locked(key) {
+		t.Errorf("Expected key %s to be unlocked once the writer released it", key)
+	}
+}
+
+func TestRWMutex_UnlockNonExistentKey(t *testing.T) {
+	km := NewRW[string]()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Unlocking a non-existent key should panic")
+		}
+	}()
+
+	km.Unlock("nonExistentKey")
+}
+
+func TestRWMutex_RUnlockNonExistentKey(t *testing.T) {
+	km := NewRW[string]()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("RUnlocking a non-existent key should panic")
+		}
+	}()
+
+	km.RUnlock("nonExistentKey")
+}